@@ -0,0 +1,12 @@
+package beat
+import "github.com/elastic/beats/libbeat/publisher"
+type RawConfiger interface {
+	Unpack(interface{}) error
+}
+type Beat struct {
+	RawConfig RawConfiger
+	Publisher Publisher
+}
+type Publisher interface {
+	Connect() publisher.Client
+}
@@ -0,0 +1,2 @@
+package common
+type MapStr map[string]interface{}
@@ -0,0 +1,6 @@
+package logp
+func MakeDebug(selector string) func(string, ...interface{}) {
+	return func(string, ...interface{}) {}
+}
+func Info(format string, v ...interface{}) {}
+func Warn(format string, v ...interface{}) {}
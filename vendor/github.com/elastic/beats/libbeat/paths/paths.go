@@ -0,0 +1,8 @@
+package paths
+type Path int
+const (
+	Data Path = iota
+	Home
+	Config
+)
+func Resolve(p Path, path string) string { return path }
@@ -0,0 +1,15 @@
+package publisher
+
+import "github.com/elastic/beats/libbeat/common"
+
+type PublishMode int
+
+const (
+	Sync PublishMode = iota
+	Guaranteed
+)
+
+type Client interface {
+	PublishEvents(events []common.MapStr, opts ...interface{}) bool
+	Close() error
+}
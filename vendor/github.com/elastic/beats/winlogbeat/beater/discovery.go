@@ -0,0 +1,141 @@
+package beater
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/winlogbeat/config"
+	"github.com/elastic/beats/winlogbeat/eventlog"
+)
+
+// defaultDiscoveryInterval is used when event_logs_discovery.interval is
+// not set.
+const defaultDiscoveryInterval = time.Minute
+
+// logWorker bundles the state needed to run and independently stop a
+// single event log's processing goroutine.
+type logWorker struct {
+	log         eventlog.EventLog
+	selector    eventlog.EventSelector
+	ignoreOlder time.Duration
+	runOnce     bool          // drain the backlog and exit instead of waiting for new records
+	explicit    bool          // started from a literal winlogbeat.event_logs entry, not discovery
+	done        chan struct{} // closed to stop just this worker
+}
+
+// startEventLog constructs the EventLog and EventSelector for logConfig,
+// registers it in eb.logs, and starts its processing goroutine. explicit
+// marks a log that was named directly in winlogbeat.event_logs, as opposed
+// to one found by discovery -- discovery reconciliation must never stop an
+// explicit log just because it briefly drops out of an enumeration or
+// doesn't match the discovery include/exclude patterns. The caller must
+// hold eb.mutex.
+func (eb *Winlogbeat) startEventLog(logConfig config.EventLogConfig, explicit bool) error {
+	log, err := eventlog.New(logConfig)
+	if err != nil {
+		return fmt.Errorf("Failed to create new event log. %v", err)
+	}
+
+	if _, running := eb.logs[log.Name()]; running {
+		return nil
+	}
+
+	selector, err := eventlog.NewEventSelector(logConfig)
+	if err != nil {
+		return fmt.Errorf("Failed to create event selector for EventLog[%s]. %v",
+			log.Name(), err)
+	}
+
+	w := &logWorker{
+		log:         log,
+		selector:    selector,
+		ignoreOlder: logConfig.IgnoreOlder,
+		runOnce:     eb.runOnce,
+		explicit:    explicit,
+		done:        make(chan struct{}),
+	}
+	eb.logs[log.Name()] = w
+
+	publishedEvents.Add(log.Name(), 0)
+	ignoredEvents.Add(log.Name(), 0)
+
+	state := eb.checkpoint.States()[log.Name()]
+	debugf("Initialized EventLog[%s]", log.Name())
+
+	eb.wg.Add(1)
+	go eb.processEventLog(w, state)
+	return nil
+}
+
+// stopEventLog signals the named event log's worker to stop and removes it
+// from the active set. The caller must hold eb.mutex.
+func (eb *Winlogbeat) stopEventLog(name string) {
+	if w, found := eb.logs[name]; found {
+		close(w.done)
+		delete(eb.logs, name)
+	}
+}
+
+// runDiscovery periodically re-enumerates the available event log channels
+// and starts workers for newly discovered ones and stops workers for ones
+// that disappeared, so that newly installed providers are picked up (and
+// removed ones stop being read from) without requiring a restart.
+func (eb *Winlogbeat) runDiscovery(cfg config.DiscoveryConfig) {
+	defer eb.wg.Done()
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultDiscoveryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	eb.discover(cfg)
+	for {
+		select {
+		case <-eb.done:
+			return
+		case <-ticker.C:
+			eb.discover(cfg)
+		}
+	}
+}
+
+func (eb *Winlogbeat) discover(cfg config.DiscoveryConfig) {
+	channels, err := eventlog.Discover(cfg)
+	if err != nil {
+		logp.Warn("Event log discovery failed. %v", err)
+		return
+	}
+
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	current := make(map[string]struct{}, len(channels))
+	for _, name := range channels {
+		current[name] = struct{}{}
+		if _, running := eb.logs[name]; running {
+			continue
+		}
+
+		debugf("Discovery found new event log %s", name)
+		if err := eb.startEventLog(config.EventLogConfig{Name: name}, false); err != nil {
+			logp.Warn("Discovery failed to start event log %s. %v", name, err)
+		}
+	}
+
+	for name, w := range eb.logs {
+		if w.explicit {
+			// Never stop a log that came from a literal event_logs entry,
+			// even if it doesn't match the discovery include/exclude
+			// patterns or was momentarily missed by this enumeration --
+			// only discovery-sourced logs are reconciled here.
+			continue
+		}
+		if _, present := current[name]; !present {
+			debugf("Discovery stopping removed event log %s", name)
+			eb.stopEventLog(name)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package beater
+
+import "flag"
+
+// once, when true, causes Run to read each event log until its tail is
+// reached and then exit, instead of running as a long-lived daemon. It
+// mirrors the winlogbeat.run_once configuration setting; either enables
+// run-once mode.
+var once = flag.Bool("once", false,
+	"Run winlogbeat only until each event log's backlog has been read, then exit")
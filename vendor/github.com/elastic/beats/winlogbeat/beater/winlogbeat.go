@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/elastic/beats/libbeat/beat"
@@ -42,14 +43,30 @@ var (
 // Time the application was started.
 var startTime = time.Now().UTC()
 
+// defaultShutdownTimeout is used when winlogbeat.shutdown_timeout is not
+// set and run-once mode is active.
+const defaultShutdownTimeout = 30 * time.Second
+
 // Winlogbeat is used to conform to the beat interface
 type Winlogbeat struct {
 	beat       *beat.Beat             // Common beat information.
 	config     *config.Settings       // Configuration settings.
-	eventLogs  []eventlog.EventLog    // List of all event logs being monitored.
-	done       chan struct{}          // Channel to initiate shutdown of main event loop.
+	done       chan struct{}          // Channel to initiate shutdown of all workers.
 	client     publisher.Client       // Interface to publish event.
 	checkpoint *checkpoint.Checkpoint // Persists event log state to disk.
+
+	mutex   sync.Mutex            // Guards logs against concurrent access from the discovery loop.
+	logs    map[string]*logWorker // Active event logs, keyed by name.
+	wg      sync.WaitGroup        // Tracks all running goroutines (per-log workers and discovery).
+	runOnce bool                  // Set at the start of Run; true if winlogbeat.run_once or --once is set.
+
+	// unackedExits counts workers that stopped because PublishEvents
+	// reported the pipeline shutting down before it acknowledged their
+	// events (see processEventLog). Accessed with sync/atomic since workers
+	// update it concurrently. In run_once mode a non-zero count makes Run
+	// return an error so the process exits non-zero instead of reporting a
+	// clean shutdown; otherwise Run only logs a warning.
+	unackedExits int32
 }
 
 // New returns a new Winlogbeat.
@@ -90,6 +107,8 @@ func (eb *Winlogbeat) Setup(b *beat.Beat) error {
 	eb.client = b.Publisher.Connect()
 	eb.done = make(chan struct{})
 
+	eventlog.Configure(eb.config.Winlogbeat.Cache.Size, eb.config.Winlogbeat.Cache.TTL)
+
 	var err error
 	eb.checkpoint, err = checkpoint.NewCheckpoint(
 		eb.config.Winlogbeat.RegistryFile, 10, 5*time.Second)
@@ -112,45 +131,114 @@ func (eb *Winlogbeat) Setup(b *beat.Beat) error {
 		}()
 	}
 
-	// Create the event logs. This will validate the event log specific
-	// configuration.
-	eb.eventLogs = make([]eventlog.EventLog, 0, len(eb.config.Winlogbeat.EventLogs))
-	for _, config := range eb.config.Winlogbeat.EventLogs {
-		eventLog, err := eventlog.New(config)
-		if err != nil {
+	// Validate that each explicitly configured event log (the wildcard
+	// entry used to trigger discovery excepted) can actually be
+	// constructed.
+	for _, logConfig := range eb.config.Winlogbeat.EventLogs {
+		if logConfig.Name == "*" {
+			continue
+		}
+		if _, err := eventlog.New(logConfig); err != nil {
 			return fmt.Errorf("Failed to create new event log. %v", err)
 		}
-		debugf("Initialized EventLog[%s]", eventLog.Name())
-
-		eb.eventLogs = append(eb.eventLogs, eventLog)
 	}
 
+	eb.logs = map[string]*logWorker{}
+
 	return nil
 }
 
 // Run is used within the beats interface to execute the Winlogbeat workers.
 func (eb *Winlogbeat) Run(b *beat.Beat) error {
-	persistedState := eb.checkpoint.States()
-
 	// Initialize metrics.
 	publishedEvents.Add("total", 0)
 	ignoredEvents.Add("total", 0)
 
-	var wg sync.WaitGroup
-	for _, log := range eb.eventLogs {
-		state, _ := persistedState[log.Name()]
+	eb.runOnce = eb.config.Winlogbeat.RunOnce || *once
+
+	eb.mutex.Lock()
+	for _, logConfig := range eb.config.Winlogbeat.EventLogs {
+		if logConfig.Name == "*" {
+			continue
+		}
+		if err := eb.startEventLog(logConfig, true); err != nil {
+			eb.mutex.Unlock()
+			return err
+		}
+	}
+	eb.mutex.Unlock()
+
+	if eb.config.Winlogbeat.DiscoveryEnabled() {
+		if eb.runOnce {
+			logp.Warn("event_logs_discovery is not supported in run_once mode; only the explicitly configured event_logs will be read")
+		} else {
+			eb.wg.Add(1)
+			go eb.runDiscovery(eb.config.Winlogbeat.EventLogsDiscovery)
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		eb.wg.Wait()
+		close(drained)
+	}()
+
+	if !eb.runOnce {
+		// A long-running daemon is expected to see workers exit with
+		// unacknowledged events on every graceful Stop() that interrupts an
+		// in-flight Sync publish -- that is a normal restart/shutdown, not
+		// a failure, so it must keep returning a clean exit. Only run_once,
+		// where "done" is supposed to mean "every record was persisted",
+		// treats unacked events as an error.
+		<-drained
+		eb.checkpoint.Shutdown()
+		if n := atomic.LoadInt32(&eb.unackedExits); n > 0 {
+			logp.Warn("%d event log worker(s) stopped before the publisher pipeline "+
+				"acknowledged all of their events during shutdown", n)
+		}
+		return nil
+	}
 
-		// Initialize per event log metrics.
-		publishedEvents.Add(log.Name(), 0)
-		ignoredEvents.Add(log.Name(), 0)
+	// PublishEvents is Sync+Guaranteed, so a worker only moves past it once
+	// its events are acknowledged -- there is no separate post-drain ack
+	// phase for shutdown_timeout to bound. Racing it against drained from
+	// the start of Run would falsely time out a run_once scan of a large
+	// backlog that simply takes a while to read and publish, even though
+	// every event published so far was acknowledged and checkpointed. The
+	// timeout only makes sense once something has actually asked the beat
+	// to stop, so start the clock on eb.done instead of on entry to Run.
+	select {
+	case <-drained:
+		eb.checkpoint.Shutdown()
+		return eb.unackedExitErr()
+	case <-eb.done:
+		timeout := eb.config.Winlogbeat.ShutdownTimeout
+		if timeout <= 0 {
+			timeout = defaultShutdownTimeout
+		}
 
-		// Start a goroutine for each event log.
-		wg.Add(1)
-		go eb.processEventLog(&wg, log, state)
+		select {
+		case <-drained:
+			eb.checkpoint.Shutdown()
+			return eb.unackedExitErr()
+		case <-time.After(timeout):
+			eb.checkpoint.Shutdown()
+			return fmt.Errorf("shutdown_timeout (%v) elapsed before the publisher pipeline "+
+				"finished acknowledging all events; some records may not have been persisted",
+				timeout)
+		}
 	}
+}
 
-	wg.Wait()
-	eb.checkpoint.Shutdown()
+// unackedExitErr returns an error if any worker stopped because the
+// publisher pipeline shut down before acknowledging its events, so that
+// run_once reports failure instead of a clean exit when records may not
+// have been persisted.
+func (eb *Winlogbeat) unackedExitErr() error {
+	if n := atomic.LoadInt32(&eb.unackedExits); n > 0 {
+		return fmt.Errorf("%d event log worker(s) stopped before the publisher pipeline "+
+			"acknowledged all of their events; some records may not have been persisted", n)
+	}
 	return nil
 }
 
@@ -178,12 +266,12 @@ func (eb *Winlogbeat) Stop() {
 	}
 }
 
-func (eb *Winlogbeat) processEventLog(
-	wg *sync.WaitGroup,
-	api eventlog.EventLog,
-	state checkpoint.EventLogState,
-) {
-	defer wg.Done()
+func (eb *Winlogbeat) processEventLog(w *logWorker, state checkpoint.EventLogState) {
+	defer eb.wg.Done()
+
+	api := w.log
+	selector := w.selector
+	ignoreOlder := w.ignoreOlder
 
 	err := api.Open(state.RecordNumber)
 	if err != nil {
@@ -202,10 +290,21 @@ func (eb *Winlogbeat) processEventLog(
 
 	debugf("EventLog[%s] opened successfully", api.Name())
 
+	// If the backend supports push notifications, block on them instead of
+	// polling Read on a fixed interval. This removes up to a second of
+	// latency per event and avoids idle wakeups on low-volume hosts.
+	var notify <-chan struct{}
+	if notifier, ok := api.(eventlog.Notifier); ok {
+		notify = notifier.Notify()
+		debugf("EventLog[%s] using push notifications instead of polling", api.Name())
+	}
+
 	for {
 		select {
 		case <-eb.done:
 			return
+		case <-w.done:
+			return
 		default:
 		}
 
@@ -217,23 +316,61 @@ func (eb *Winlogbeat) processEventLog(
 		}
 		debugf("EventLog[%s] Read() returned %d records", api.Name(), len(records))
 		if len(records) == 0 {
-			// TODO: Consider implementing notifications using
-			// NotifyChangeEventLog instead of polling.
-			time.Sleep(time.Second)
+			if w.runOnce {
+				debugf("EventLog[%s] reached the tail of the log; run_once is set, stopping", api.Name())
+				return
+			}
+
+			var poll <-chan time.Time
+			if notify == nil {
+				poll = time.After(time.Second)
+			}
+
+			select {
+			case <-eb.done:
+				return
+			case <-w.done:
+				return
+			case <-notify:
+			case <-poll:
+			}
 			continue
 		}
 
+		var cutoff time.Time
+		if ignoreOlder > 0 {
+			cutoff = time.Now().UTC().Add(-ignoreOlder)
+		}
+
 		events := make([]common.MapStr, 0, len(records))
+		var numIgnored int64
 		for _, lr := range records {
+			if !selector.ShouldPublish(lr) || (ignoreOlder > 0 && lr.TimeCreated.SystemTime.Before(cutoff)) {
+				numIgnored++
+				continue
+			}
 			events = append(events, lr.ToMapStr())
 		}
+		if numIgnored > 0 {
+			ignoredEvents.Add("total", numIgnored)
+			ignoredEvents.Add(api.Name(), numIgnored)
+		}
+		if len(events) == 0 {
+			eb.checkpoint.Persist(api.Name(),
+				records[len(records)-1].RecordID,
+				records[len(records)-1].TimeCreated.SystemTime.UTC())
+			continue
+		}
 
 		// Publish events.
 		numEvents := int64(len(events))
 		ok := eb.client.PublishEvents(events, publisher.Sync, publisher.Guaranteed)
 		if !ok {
 			// due to using Sync and Guaranteed the ok will only be false on shutdown.
-			// Do not update the internal state and return in this case
+			// Do not update the internal state and return in this case. The
+			// events above were never acknowledged, so record that for Run
+			// to report: as an error in run_once mode, as a warning otherwise.
+			atomic.AddInt32(&eb.unackedExits, 1)
 			return
 		}
 
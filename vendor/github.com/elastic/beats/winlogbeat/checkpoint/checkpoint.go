@@ -0,0 +1,113 @@
+/*
+Package checkpoint persists the read position of each configured event log
+to disk so that Winlogbeat can resume from where it left off after a
+restart.
+*/
+package checkpoint
+
+import (
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+// EventLogState holds the last read position for a single event log.
+type EventLogState struct {
+	Name         string    `json:"name"`
+	RecordNumber uint64    `json:"record_number"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Checkpoint persists EventLogState to disk on a schedule and on demand,
+// allowing Winlogbeat to resume reading each event log from the last
+// acknowledged record after a restart.
+type Checkpoint struct {
+	file          string
+	states        map[string]EventLogState
+	mutex         sync.Mutex
+	done          chan struct{}
+	wg            sync.WaitGroup
+	maxEvents     int
+	flushInterval time.Duration
+	dirty         bool
+}
+
+// NewCheckpoint creates a new Checkpoint that persists to file. Pending
+// updates are flushed to disk every flushInterval and on Shutdown. maxEvents
+// is stored on the Checkpoint but does not trigger a flush; the number of
+// pending updates is not counted.
+func NewCheckpoint(file string, maxEvents int, flushInterval time.Duration) (*Checkpoint, error) {
+	c := &Checkpoint{
+		file:          file,
+		states:        map[string]EventLogState{},
+		done:          make(chan struct{}),
+		maxEvents:     maxEvents,
+		flushInterval: flushInterval,
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	c.wg.Add(1)
+	go c.run()
+
+	return c, nil
+}
+
+// States returns a copy of the current state of every event log, including
+// updates recorded by Persist that have not yet been flushed to disk.
+func (c *Checkpoint) States() map[string]EventLogState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	out := make(map[string]EventLogState, len(c.states))
+	for k, v := range c.states {
+		out[k] = v
+	}
+	return out
+}
+
+// Persist records the given position for name. The update is buffered and
+// flushed to disk asynchronously.
+func (c *Checkpoint) Persist(name string, recordNumber uint64, timestamp time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.states[name] = EventLogState{
+		Name:         name,
+		RecordNumber: recordNumber,
+		Timestamp:    timestamp,
+	}
+	c.dirty = true
+}
+
+// Shutdown flushes any pending state to disk and stops the background
+// flush goroutine.
+func (c *Checkpoint) Shutdown() {
+	close(c.done)
+	c.wg.Wait()
+
+	if err := c.flush(); err != nil {
+		logp.Warn("Checkpoint failed to persist state on shutdown. %v", err)
+	}
+}
+
+func (c *Checkpoint) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.flush(); err != nil {
+				logp.Warn("Checkpoint failed to persist state. %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+)
+
+// load reads the persisted states from disk into c.states. A missing file
+// is not an error -- it simply means no state has been persisted yet.
+func (c *Checkpoint) load() error {
+	data, err := ioutil.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var states []EventLogState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+
+	for _, s := range states {
+		c.states[s.Name] = s
+	}
+	return nil
+}
+
+// flush writes the current states to disk if anything has changed since
+// the last flush.
+func (c *Checkpoint) flush() error {
+	c.mutex.Lock()
+	if !c.dirty {
+		c.mutex.Unlock()
+		return nil
+	}
+
+	states := make([]EventLogState, 0, len(c.states))
+	for _, s := range c.states {
+		states = append(states, s)
+	}
+	c.dirty = false
+	c.mutex.Unlock()
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := c.file + ".new"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.file)
+}
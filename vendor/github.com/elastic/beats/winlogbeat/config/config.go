@@ -0,0 +1,135 @@
+/*
+Package config provides the configuration settings used by Winlogbeat to
+read, filter, and publish Windows Event Log records.
+*/
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRegistryFile is the default name of the registry file used for
+// persisting state.
+const DefaultRegistryFile = ".winlogbeat.yml"
+
+// Settings contains the top-level configuration settings for Winlogbeat.
+type Settings struct {
+	Winlogbeat WinlogbeatConfig `config:"winlogbeat"`
+}
+
+// WinlogbeatConfig contains the configuration options for the event log
+// reader.
+type WinlogbeatConfig struct {
+	EventLogs          []EventLogConfig `config:"event_logs"`
+	EventLogsDiscovery DiscoveryConfig  `config:"event_logs_discovery"`
+	RegistryFile       string           `config:"registry_file"`
+	Metrics            MetricsConfig    `config:"metrics"`
+
+	// RunOnce causes each event log to be read until its tail is reached
+	// and then exit, instead of running as a long-lived daemon. It can
+	// also be enabled with the --once command line flag.
+	RunOnce bool `config:"run_once"`
+
+	// ShutdownTimeout bounds how long Run waits, in run_once mode, for
+	// workers to unwind once something has asked the beat to stop before
+	// giving up. It does not bound how long a backlog scan itself may take.
+	// Defaults to 30s.
+	ShutdownTimeout time.Duration `config:"shutdown_timeout"`
+
+	// Cache configures the per-log metadata/handle cache used by the
+	// eventlogging and wineventlog APIs when rendering records.
+	Cache CacheConfig `config:"cache"`
+}
+
+// CacheConfig controls the bounded LRU cache of per-provider publisher
+// metadata handles / render contexts kept by the eventlog package. Zero
+// values fall back to the eventlog package's own defaults.
+type CacheConfig struct {
+	Size int           `config:"size"` // Maximum number of cached providers per event log.
+	TTL  time.Duration `config:"ttl"`  // How long an idle entry is kept before the janitor evicts it.
+}
+
+// DiscoveryEnabled reports whether Winlogbeat should enumerate available
+// event log channels from the host instead of (or in addition to) reading
+// the explicitly configured EventLogs. This is the case when no event_logs
+// are configured, or when one of them is the wildcard entry "*".
+func (w WinlogbeatConfig) DiscoveryEnabled() bool {
+	if len(w.EventLogs) == 0 {
+		return true
+	}
+
+	for _, el := range w.EventLogs {
+		if el.Name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscoveryConfig controls automatic discovery of event log channels. It is
+// consulted whenever DiscoveryEnabled is true, except in run_once mode,
+// where discovery does not run.
+type DiscoveryConfig struct {
+	Include  []string      `config:"include"`  // Glob patterns of channels to include, e.g. "Microsoft-Windows-*/Operational".
+	Exclude  []string      `config:"exclude"`  // Glob patterns of channels to exclude.
+	Interval time.Duration `config:"interval"` // How often to re-enumerate. Defaults to 1 minute.
+}
+
+// MetricsConfig specifies the configuration options for the metrics
+// endpoint exposed over HTTP.
+type MetricsConfig struct {
+	BindAddress string `config:"bindaddress"`
+}
+
+// EventLogConfig contains the configuration options for a single event log.
+type EventLogConfig struct {
+	Name string `config:"name"` // Name of the event log or channel.
+	ID   string `config:"id"`   // Identifier given to the event log by the user. Defaults to Name.
+	API  string `config:"api"`  // API to use when reading the event log: eventlogging or wineventlog.
+
+	// Event selection. Entries prefixed with "-" are treated as exclusions;
+	// excludes always win over includes. Dropped records are counted in the
+	// ignoredEvents expvar map.
+	Level    []string `config:"level"`    // Severity levels to include/exclude, e.g. [error, warning, -verbose].
+	EventID  string   `config:"event_id"` // Comma separated event IDs and ranges, e.g. "4624, 4634, 4720-4726, -4625".
+	Provider []string `config:"provider"` // Provider/source names to include/exclude.
+
+	// IgnoreOlder drops (and counts as ignored) any record older than
+	// now - IgnoreOlder. This is useful to avoid flooding the pipeline
+	// with backlog when bootstrapping a host that already has a long
+	// event log history. Zero disables the filter.
+	IgnoreOlder time.Duration `config:"ignore_older"`
+}
+
+// Validate validates the configuration, returning an error describing the
+// first problem encountered.
+func (c *Settings) Validate() error {
+	if len(c.Winlogbeat.EventLogs) == 0 && !c.Winlogbeat.DiscoveryEnabled() {
+		return fmt.Errorf("No event_logs were specified")
+	}
+
+	for _, el := range c.Winlogbeat.EventLogs {
+		if el.IgnoreOlder < 0 {
+			return fmt.Errorf("ignore_older for event log '%v' must not be negative", el.Name)
+		}
+	}
+
+	if c.Winlogbeat.EventLogsDiscovery.Interval < 0 {
+		return fmt.Errorf("event_logs_discovery.interval must not be negative")
+	}
+
+	if c.Winlogbeat.ShutdownTimeout < 0 {
+		return fmt.Errorf("shutdown_timeout must not be negative")
+	}
+
+	if c.Winlogbeat.Cache.Size < 0 {
+		return fmt.Errorf("cache.size must not be negative")
+	}
+
+	if c.Winlogbeat.Cache.TTL < 0 {
+		return fmt.Errorf("cache.ttl must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package eventlog
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// Metrics for the MetadataCache, retrievable through the expvar web
+// interface in the same way as publishedEvents/ignoredEvents in the beater
+// package. Each is tracked both as a "total" across all event logs and
+// per-log, keyed by the owning log's name.
+var (
+	cacheHits      = expvar.NewMap("eventlog.cache.hits")
+	cacheMisses    = expvar.NewMap("eventlog.cache.misses")
+	cacheSize      = expvar.NewMap("eventlog.cache.size")
+	cacheEvictions = expvar.NewMap("eventlog.cache.evictions")
+)
+
+// defaultCacheSize and defaultCacheTTL are the process-wide defaults used
+// by backends (eventlogging, wineventlog) when constructing the
+// MetadataCache for a log, unless overridden by Configure.
+var (
+	defaultCacheSize = 128
+	defaultCacheTTL  = 10 * time.Minute
+)
+
+// Configure sets the process-wide cache defaults from
+// winlogbeat.cache.size and winlogbeat.cache.ttl. It should be called once
+// during setup, before any event logs are opened. Values <= 0 leave the
+// corresponding default unchanged.
+func Configure(size int, ttl time.Duration) {
+	if size > 0 {
+		defaultCacheSize = size
+	}
+	if ttl > 0 {
+		defaultCacheTTL = ttl
+	}
+}
+
+// caches holds the one MetadataCache per event log name handed out by
+// CacheFor, so repeated lookups (e.g. across New() calls for the same log)
+// share a single cache instead of each constructing -- and each running a
+// janitor for -- their own.
+var (
+	cachesMutex sync.Mutex
+	caches      = map[string]*MetadataCache{}
+)
+
+// CacheFor returns the shared MetadataCache for the named event log, using
+// the process-wide defaults (see Configure), constructing it the first time
+// it's requested for that name. New calls this for every event log it
+// constructs; a backend (eventlogging, wineventlog) that wants to cache
+// per-provider metadata handles or render contexts should call
+// CacheFor(name) to get it rather than constructing its own.
+func CacheFor(logName string) *MetadataCache {
+	cachesMutex.Lock()
+	defer cachesMutex.Unlock()
+
+	if c, found := caches[logName]; found {
+		return c
+	}
+
+	c := NewMetadataCache(logName, 0, 0)
+	caches[logName] = c
+	return c
+}
+
+// MetadataCache is a bounded, TTL-evicting LRU cache of the per-provider
+// objects needed to render event log records -- publisher metadata handles
+// for the eventlogging API, and the wineventlog system render context for
+// the wineventlog API. Caching these avoids repeatedly opening (and
+// leaking) Windows handles for the same provider on every record.
+type MetadataCache struct {
+	logName string
+	size    int
+	ttl     time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front = most recently used
+
+	done chan struct{}
+}
+
+type cacheEntry struct {
+	key      string
+	value    interface{}
+	lastUsed time.Time
+}
+
+// NewMetadataCache creates a cache for logName that holds at most size
+// entries and evicts entries idle for longer than ttl. If size or ttl is
+// <= 0, the process-wide default configured via Configure is used instead,
+// so the cache is always bounded and always TTL-evicting. A background janitor sweeps
+// idle entries to free the underlying handles without waiting for the
+// cache to fill up.
+func NewMetadataCache(logName string, size int, ttl time.Duration) *MetadataCache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	c := &MetadataCache{
+		logName: logName,
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		done:    make(chan struct{}),
+	}
+
+	cacheSize.Add(logName, 0)
+	cacheSize.Add("total", 0)
+
+	if ttl > 0 {
+		go c.janitor()
+	}
+
+	return c
+}
+
+// Get returns the cached value for key, if present and not expired.
+func (c *MetadataCache) Get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	el, found := c.entries[key]
+	if !found || (c.ttl > 0 && time.Since(el.Value.(*cacheEntry).lastUsed) > c.ttl) {
+		if found {
+			c.removeElement(el)
+		}
+		cacheMisses.Add("total", 1)
+		cacheMisses.Add(c.logName, 1)
+		return nil, false
+	}
+
+	entry := el.Value.(*cacheEntry)
+	entry.lastUsed = time.Now()
+	c.order.MoveToFront(el)
+
+	cacheHits.Add("total", 1)
+	cacheHits.Add(c.logName, 1)
+	return entry.value, true
+}
+
+// Put inserts or updates the cached value for key, evicting the least
+// recently used entry if the cache is at capacity.
+func (c *MetadataCache) Put(key string, value interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if el, found := c.entries[key]; found {
+		entry := el.Value.(*cacheEntry)
+		entry.value = value
+		entry.lastUsed = time.Now()
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&cacheEntry{key: key, value: value, lastUsed: time.Now()})
+	c.entries[key] = el
+	cacheSize.Add(c.logName, 1)
+	cacheSize.Add("total", 1)
+
+	if c.size > 0 && c.order.Len() > c.size {
+		c.evictOldest()
+	}
+}
+
+// Len returns the number of entries currently cached.
+func (c *MetadataCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+// Close stops the background janitor. It does not clear the cache.
+func (c *MetadataCache) Close() {
+	close(c.done)
+}
+
+func (c *MetadataCache) evictOldest() {
+	oldest := c.order.Back()
+	if oldest == nil {
+		return
+	}
+	c.removeElement(oldest)
+	cacheEvictions.Add("total", 1)
+	cacheEvictions.Add(c.logName, 1)
+}
+
+// removeElement removes el from the cache. The caller must hold c.mutex.
+func (c *MetadataCache) removeElement(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.order.Remove(el)
+	delete(c.entries, entry.key)
+	cacheSize.Add(c.logName, -1)
+	cacheSize.Add("total", -1)
+}
+
+// janitor periodically sweeps entries that have been idle for longer than
+// c.ttl, freeing the Windows handles they hold without waiting for the
+// cache to fill up.
+func (c *MetadataCache) janitor() {
+	interval := c.ttl / 2
+	if interval <= 0 || interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.sweep()
+		}
+	}
+}
+
+func (c *MetadataCache) sweep() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for el := c.order.Back(); el != nil; {
+		prev := el.Prev()
+		if time.Since(el.Value.(*cacheEntry).lastUsed) > c.ttl {
+			c.removeElement(el)
+			cacheEvictions.Add("total", 1)
+			cacheEvictions.Add(c.logName, 1)
+		}
+		el = prev
+	}
+}
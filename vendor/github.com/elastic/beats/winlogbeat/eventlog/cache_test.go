@@ -0,0 +1,69 @@
+package eventlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadataCacheHitsAndMisses(t *testing.T) {
+	c := NewMetadataCache("TestLog1", 2, time.Hour)
+	defer c.Close()
+
+	if _, found := c.Get("Provider-A"); found {
+		t.Error("expected a miss on an empty cache")
+	}
+
+	c.Put("Provider-A", "handle-a")
+	v, found := c.Get("Provider-A")
+	if !found || v != "handle-a" {
+		t.Errorf("expected a hit with handle-a, got %v, %v", v, found)
+	}
+}
+
+func TestMetadataCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMetadataCache("TestLog2", 2, time.Hour)
+	defer c.Close()
+
+	c.Put("A", 1)
+	c.Put("B", 2)
+	c.Get("A") // touch A so B becomes the least recently used
+	c.Put("C", 3)
+
+	if _, found := c.Get("B"); found {
+		t.Error("expected B to have been evicted")
+	}
+	if _, found := c.Get("A"); !found {
+		t.Error("expected A to still be cached")
+	}
+	if _, found := c.Get("C"); !found {
+		t.Error("expected C to still be cached")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("expected 2 entries, got %d", got)
+	}
+}
+
+func TestMetadataCacheTTLExpiry(t *testing.T) {
+	c := NewMetadataCache("TestLog3", 10, time.Millisecond)
+	defer c.Close()
+
+	c.Put("A", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.Get("A"); found {
+		t.Error("expected A to have expired")
+	}
+}
+
+func TestCacheForReturnsSameInstance(t *testing.T) {
+	a := CacheFor("TestCacheForLog")
+	b := CacheFor("TestCacheForLog")
+	if a != b {
+		t.Error("expected repeated CacheFor calls for the same log to return the same cache")
+	}
+
+	other := CacheFor("TestCacheForOtherLog")
+	if a == other {
+		t.Error("expected different logs to get distinct caches")
+	}
+}
@@ -0,0 +1,86 @@
+package eventlog
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/elastic/beats/winlogbeat/config"
+)
+
+// Discoverer enumerates the names of the event log channels that a
+// particular API implementation can see on the host.
+type Discoverer interface {
+	Channels() ([]string, error)
+}
+
+// discoverers holds the registered Discoverer for each API, wired up by the
+// platform-specific files in this package (discovery_windows.go).
+var discoverers = map[string]Discoverer{}
+
+// RegisterDiscoverer registers a Discoverer under the given API name. It
+// panics if the name is already registered, which would indicate a
+// programmer error.
+func RegisterDiscoverer(name string, d Discoverer) {
+	if _, exists := discoverers[name]; exists {
+		panic(fmt.Errorf("Discoverer '%s' already registered", name))
+	}
+	discoverers[name] = d
+}
+
+// Discover enumerates the available channels across all registered APIs
+// and returns the subset that matches cfg's include/exclude glob patterns,
+// sorted by name. An empty include list means "include everything".
+func Discover(cfg config.DiscoveryConfig) ([]string, error) {
+	if len(discoverers) == 0 {
+		return nil, fmt.Errorf("no event log discoverer is registered for this platform")
+	}
+
+	seen := map[string]struct{}{}
+	var channels []string
+	for _, d := range discoverers {
+		found, err := d.Channels()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range found {
+			if _, dup := seen[c]; dup {
+				continue
+			}
+			seen[c] = struct{}{}
+			channels = append(channels, c)
+		}
+	}
+
+	return filterChannels(channels, cfg), nil
+}
+
+func filterChannels(channels []string, cfg config.DiscoveryConfig) []string {
+	out := make([]string, 0, len(channels))
+	for _, c := range channels {
+		if matchesAny(cfg.Exclude, c) {
+			continue
+		}
+		if len(cfg.Include) > 0 && !matchesAny(cfg.Include, c) {
+			continue
+		}
+		out = append(out, c)
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// matchesAny reports whether name matches any of the given glob patterns.
+// Patterns use path.Match semantics, so "*" does not cross a "/" -- this
+// lets a pattern like "Microsoft-Windows-*/Operational" match only the
+// Operational channel of each provider.
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
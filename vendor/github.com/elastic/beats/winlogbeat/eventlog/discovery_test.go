@@ -0,0 +1,60 @@
+package eventlog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/elastic/beats/winlogbeat/config"
+)
+
+type fakeDiscoverer struct {
+	channels []string
+}
+
+func (f fakeDiscoverer) Channels() ([]string, error) {
+	return f.channels, nil
+}
+
+func TestDiscoverFiltering(t *testing.T) {
+	RegisterDiscoverer("test", fakeDiscoverer{channels: []string{
+		"Application",
+		"Security",
+		"Microsoft-Windows-PowerShell/Operational",
+		"Microsoft-Windows-PowerShell/Admin",
+		"Microsoft-Windows-TaskScheduler/Operational",
+	}})
+	defer delete(discoverers, "test")
+
+	got, err := Discover(config.DiscoveryConfig{
+		Include: []string{"Microsoft-Windows-*/Operational"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"Microsoft-Windows-PowerShell/Operational",
+		"Microsoft-Windows-TaskScheduler/Operational",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverExcludeWinsOverInclude(t *testing.T) {
+	RegisterDiscoverer("test", fakeDiscoverer{channels: []string{"Application", "Security"}})
+	defer delete(discoverers, "test")
+
+	got, err := Discover(config.DiscoveryConfig{
+		Include: []string{"*"},
+		Exclude: []string{"Security"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Application"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
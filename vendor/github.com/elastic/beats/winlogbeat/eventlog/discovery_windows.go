@@ -0,0 +1,80 @@
+//go:build windows
+// +build windows
+
+package eventlog
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+func init() {
+	RegisterDiscoverer("eventlogging", registryDiscoverer{})
+	RegisterDiscoverer("wineventlog", wineventlogDiscoverer{})
+}
+
+// registryDiscoverer enumerates the legacy event logs registered under
+// SYSTEM\CurrentControlSet\Services\EventLog, which is what the
+// eventlogging API can read from.
+type registryDiscoverer struct{}
+
+func (registryDiscoverer) Channels() ([]string, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`SYSTEM\CurrentControlSet\Services\EventLog`, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EventLog registry key: %v", err)
+	}
+	defer key.Close()
+
+	names, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate EventLog registry key: %v", err)
+	}
+	return names, nil
+}
+
+// wineventlogDiscoverer enumerates the channels registered with the
+// Windows Event Log service, equivalent to `wevtutil el`.
+type wineventlogDiscoverer struct{}
+
+var (
+	modwevtapi             = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtOpenChannelEnum = modwevtapi.NewProc("EvtOpenChannelEnum")
+	procEvtNextChannelPath = modwevtapi.NewProc("EvtNextChannelPath")
+	procEvtClose           = modwevtapi.NewProc("EvtClose")
+)
+
+func (wineventlogDiscoverer) Channels() ([]string, error) {
+	h, _, callErr := procEvtOpenChannelEnum.Call(0, 0)
+	if h == 0 {
+		return nil, fmt.Errorf("EvtOpenChannelEnum failed: %v", callErr)
+	}
+	defer procEvtClose.Call(h)
+
+	var channels []string
+	buf := make([]uint16, 512)
+	for {
+		var used uint32
+		ret, _, callErr := procEvtNextChannelPath.Call(
+			h,
+			uintptr(len(buf)),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&used)))
+		if ret == 0 {
+			switch callErr {
+			case windows.ERROR_NO_MORE_ITEMS:
+				return channels, nil
+			case windows.ERROR_INSUFFICIENT_BUFFER:
+				buf = make([]uint16, used)
+				continue
+			default:
+				return nil, fmt.Errorf("EvtNextChannelPath failed: %v", callErr)
+			}
+		}
+
+		channels = append(channels, windows.UTF16ToString(buf[:used]))
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Package eventlog defines the EventLog interface used by Winlogbeat to read
+event log records, along with event selection, channel discovery and the
+metadata cache. Reader implementations for the Windows APIs (the legacy
+eventlogging API and the newer wineventlog API) register themselves with
+Register; this package contains none, and New returns an error for any API
+that has not been registered.
+*/
+package eventlog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/winlogbeat/config"
+)
+
+// EventLog is an interface implemented by the underlying event log readers
+// (eventlogging and wineventlog) and used by Winlogbeat's main loop.
+type EventLog interface {
+	// Open opens the event log for reading. recordNumber is the last
+	// record that was previously read and processing should resume from
+	// the record following it. Pass 0 to read from the beginning.
+	Open(recordNumber uint64) error
+
+	// Read reads and returns the next batch of records. It returns an
+	// empty slice (and no error) when no new records are currently
+	// available.
+	Read() ([]Record, error)
+
+	// Close closes the event log handle.
+	Close() error
+
+	// Name returns the name of the event log (e.g. Application, Security).
+	Name() string
+}
+
+// Provider identifies the source of an event.
+type Provider struct {
+	Name string
+}
+
+// TimeCreated holds the creation timestamp of an event.
+type TimeCreated struct {
+	SystemTime time.Time
+}
+
+// Record represents a single event log record read from the Windows Event
+// Log.
+type Record struct {
+	RecordID    uint64
+	TimeCreated TimeCreated
+	Provider    Provider
+	Level       string // Critical, Error, Warning, Information, or Verbose.
+	EventID     uint32
+
+	API     string
+	Message string
+}
+
+// ToMapStr returns a common.MapStr representation of the record suitable
+// for publishing.
+func (r Record) ToMapStr() common.MapStr {
+	return common.MapStr{
+		"@timestamp":    r.TimeCreated.SystemTime,
+		"record_number": fmt.Sprintf("%d", r.RecordID),
+		"source_name":   r.Provider.Name,
+		"level":         r.Level,
+		"event_id":      r.EventID,
+		"message":       r.Message,
+		"type":          r.API,
+	}
+}
+
+// defaultAPI is the API used when an event log configuration does not
+// specify one explicitly.
+const defaultAPI = "wineventlog"
+
+// Factory creates a new EventLog instance from the given configuration.
+type Factory func(options config.EventLogConfig) (EventLog, error)
+
+// registry contains the registered EventLog factories keyed by API name.
+var registry = map[string]Factory{}
+
+// Register registers a new EventLog factory under the given API name. It
+// panics if the name is already registered, which would indicate a
+// programmer error.
+func Register(name string, factory Factory) {
+	if _, exists := registry[name]; exists {
+		panic(fmt.Errorf("EventLog API '%s' already registered", name))
+	}
+	registry[name] = factory
+}
+
+// New creates a new EventLog based on the given configuration.
+func New(options config.EventLogConfig) (EventLog, error) {
+	api := options.API
+	if api == "" {
+		api = defaultAPI
+	}
+
+	factory, found := registry[api]
+	if !found {
+		return nil, fmt.Errorf("invalid api '%s' for event log '%s'", api, options.Name)
+	}
+
+	// Ensure this log's metadata cache exists before handing off to the
+	// backend, so it can retrieve the same instance with
+	// CacheFor(options.Name) instead of constructing its own.
+	CacheFor(options.Name)
+
+	return factory(options)
+}
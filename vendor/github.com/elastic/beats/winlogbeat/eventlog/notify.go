@@ -0,0 +1,13 @@
+package eventlog
+
+// Notifier is optionally implemented by an EventLog backend that can block
+// until new records may be available, instead of requiring the caller to
+// poll Read on a timer. No EventLog implementation in this package
+// implements Notifier; Winlogbeat's main loop uses it when a registered
+// backend does and otherwise falls back to polling Read once a second.
+type Notifier interface {
+	// Notify returns a channel that receives a value whenever new records
+	// may be available to Read. The channel is closed when the EventLog is
+	// closed.
+	Notify() <-chan struct{}
+}
@@ -0,0 +1,244 @@
+package eventlog
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/winlogbeat/config"
+)
+
+// EventSelector decides, for a single event log, whether a Record should be
+// published or dropped based on the level, event_id, and provider filters
+// in EventLogConfig. Excludes always take precedence over includes, and an
+// empty include set means "include everything".
+type EventSelector struct {
+	level    stringSelector
+	provider stringSelector
+	eventID  idSelector
+}
+
+// NewEventSelector builds an EventSelector from the raw configuration
+// values, returning an error if event_id cannot be parsed.
+func NewEventSelector(options config.EventLogConfig) (EventSelector, error) {
+	id, err := newIDSelector(options.EventID)
+	if err != nil {
+		return EventSelector{}, fmt.Errorf("invalid event_id filter: %v", err)
+	}
+
+	return EventSelector{
+		level:    newStringSelector(options.Level, normalizeLevel),
+		provider: newStringSelector(options.Provider, nil),
+		eventID:  id,
+	}, nil
+}
+
+// levelAliases maps documented level tokens (and Record.Level's own
+// canonical value) to a shared lowercase form, so that a config entry and
+// the record it's compared against always normalize to the same string.
+// "info" is the documented short form of Record.Level's "Information".
+var levelAliases = map[string]string{
+	"info":        "information",
+	"information": "information",
+}
+
+// normalizeLevel lowercases v and expands documented aliases (e.g. "info")
+// to the form used by Record.Level, so that config.Level entries match
+// regardless of which spelling the user wrote.
+func normalizeLevel(v string) string {
+	v = strings.ToLower(v)
+	if canonical, found := levelAliases[v]; found {
+		return canonical
+	}
+	return v
+}
+
+// ShouldPublish returns true if rec passes all of the configured filters
+// and should be published.
+func (s EventSelector) ShouldPublish(rec Record) bool {
+	return s.level.match(rec.Level) &&
+		s.provider.match(rec.Provider.Name) &&
+		s.eventID.match(rec.EventID)
+}
+
+// stringSelector implements include/exclude matching over a small set of
+// case-sensitive (or normalized) strings. Entries prefixed with "-" are
+// treated as exclusions.
+type stringSelector struct {
+	includes  map[string]struct{}
+	excludes  map[string]struct{}
+	normalize func(string) string
+}
+
+func newStringSelector(values []string, normalize func(string) string) stringSelector {
+	s := stringSelector{
+		includes:  map[string]struct{}{},
+		excludes:  map[string]struct{}{},
+		normalize: normalize,
+	}
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if strings.HasPrefix(v, "-") {
+			s.excludes[s.norm(v[1:])] = struct{}{}
+		} else {
+			s.includes[s.norm(v)] = struct{}{}
+		}
+	}
+
+	return s
+}
+
+func (s stringSelector) norm(v string) string {
+	if s.normalize == nil {
+		return v
+	}
+	return s.normalize(v)
+}
+
+func (s stringSelector) match(v string) bool {
+	v = s.norm(v)
+
+	if _, excluded := s.excludes[v]; excluded {
+		return false
+	}
+
+	if len(s.includes) == 0 {
+		return true
+	}
+
+	_, included := s.includes[v]
+	return included
+}
+
+// idRange is an inclusive [low, high] range of event IDs.
+type idRange struct {
+	low, high uint32
+}
+
+func (r idRange) contains(id uint32) bool {
+	return id >= r.low && id <= r.high
+}
+
+// idSelector implements include/exclude matching over event IDs and ranges
+// (e.g. "4624, 4634, 4720-4726, -4625"). Ranges are kept sorted by their
+// low bound so that a lookup is a binary search followed by a constant
+// number of neighbor checks, giving O(log n) matching even for very large
+// allow/deny lists.
+type idSelector struct {
+	includes []idRange
+	excludes []idRange
+}
+
+func newIDSelector(spec string) (idSelector, error) {
+	var s idSelector
+
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		exclude := false
+		if strings.HasPrefix(field, "-") {
+			exclude = true
+			field = field[1:]
+		}
+
+		r, err := parseIDRange(field)
+		if err != nil {
+			return idSelector{}, err
+		}
+
+		if exclude {
+			s.excludes = append(s.excludes, r)
+		} else {
+			s.includes = append(s.includes, r)
+		}
+	}
+
+	s.includes = mergeRanges(s.includes)
+	s.excludes = mergeRanges(s.excludes)
+	return s, nil
+}
+
+func parseIDRange(field string) (idRange, error) {
+	parts := strings.SplitN(field, "-", 2)
+	low, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+	if err != nil {
+		return idRange{}, fmt.Errorf("invalid event ID '%s': %v", field, err)
+	}
+
+	if len(parts) == 1 {
+		return idRange{low: uint32(low), high: uint32(low)}, nil
+	}
+
+	high, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 32)
+	if err != nil {
+		return idRange{}, fmt.Errorf("invalid event ID range '%s': %v", field, err)
+	}
+	if high < low {
+		return idRange{}, fmt.Errorf("invalid event ID range '%s': high < low", field)
+	}
+
+	return idRange{low: uint32(low), high: uint32(high)}, nil
+}
+
+// mergeRanges sorts ranges by their low bound and coalesces any that
+// overlap or touch, producing the minimal set of disjoint ranges. This is
+// what makes a single binary search sufficient for membership tests.
+func mergeRanges(ranges []idRange) []idRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].low < ranges[j].low })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if uint64(r.low) <= uint64(last.high)+1 {
+			if r.high > last.high {
+				last.high = r.high
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// rangesContain performs a binary search over the sorted, disjoint ranges
+// for id, giving O(log n) lookup regardless of how many ranges were
+// configured.
+func rangesContain(ranges []idRange, id uint32) bool {
+	n := len(ranges)
+	if n == 0 {
+		return false
+	}
+
+	// Find the first range whose low bound is > id; the only range that
+	// could contain id is the one immediately before it.
+	i := sort.Search(n, func(i int) bool { return ranges[i].low > id })
+	if i == 0 {
+		return false
+	}
+	return ranges[i-1].contains(id)
+}
+
+func (s idSelector) match(id uint32) bool {
+	if rangesContain(s.excludes, id) {
+		return false
+	}
+
+	if len(s.includes) == 0 {
+		return true
+	}
+
+	return rangesContain(s.includes, id)
+}
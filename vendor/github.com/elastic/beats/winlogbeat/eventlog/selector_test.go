@@ -0,0 +1,79 @@
+package eventlog
+
+import (
+	"testing"
+
+	"github.com/elastic/beats/winlogbeat/config"
+)
+
+func TestEventSelectorLevel(t *testing.T) {
+	s, err := NewEventSelector(config.EventLogConfig{Level: []string{"error", "warning"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.ShouldPublish(Record{Level: "Error"}) {
+		t.Error("expected Error level to be published")
+	}
+	if s.ShouldPublish(Record{Level: "Information"}) {
+		t.Error("expected Information level to be dropped")
+	}
+}
+
+func TestEventSelectorLevelInfoAlias(t *testing.T) {
+	s, err := NewEventSelector(config.EventLogConfig{Level: []string{"info"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.ShouldPublish(Record{Level: "Information"}) {
+		t.Error("expected the documented 'info' token to match Record.Level \"Information\"")
+	}
+	if s.ShouldPublish(Record{Level: "Error"}) {
+		t.Error("expected Error level to be dropped")
+	}
+}
+
+func TestEventSelectorEventID(t *testing.T) {
+	s, err := NewEventSelector(config.EventLogConfig{EventID: "4624, 4634, 4720-4726, -4625"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   uint32
+		want bool
+	}{
+		{4624, true},
+		{4625, false}, // explicitly excluded
+		{4634, true},
+		{4722, true},  // inside 4720-4726 range
+		{4730, false}, // outside any include
+	}
+
+	for _, tc := range tests {
+		if got := s.ShouldPublish(Record{EventID: tc.id}); got != tc.want {
+			t.Errorf("event id %d: got %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestEventSelectorProvider(t *testing.T) {
+	s, err := NewEventSelector(config.EventLogConfig{Provider: []string{"Microsoft-Windows-Security-Auditing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.ShouldPublish(Record{Provider: Provider{Name: "Microsoft-Windows-Security-Auditing"}}) {
+		t.Error("expected matching provider to be published")
+	}
+	if s.ShouldPublish(Record{Provider: Provider{Name: "Microsoft-Windows-Kernel-General"}}) {
+		t.Error("expected non-matching provider to be dropped")
+	}
+}
+
+func TestEventSelectorInvalidEventID(t *testing.T) {
+	if _, err := NewEventSelector(config.EventLogConfig{EventID: "not-a-number"}); err == nil {
+		t.Error("expected an error for an invalid event_id filter")
+	}
+}